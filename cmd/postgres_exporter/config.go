@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Target is a single Postgres instance this exporter can probe via /probe?target=<name>.
+type Target struct {
+	Name       string   `yaml:"name"`
+	DSN        string   `yaml:"dsn"`
+	Collectors []string `yaml:"collectors,omitempty"`
+}
+
+// Config is the top-level shape of the --config.file YAML document.
+type Config struct {
+	Targets []Target `yaml:"targets"`
+}
+
+// LoadConfig reads and validates the multi-target config file at path.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config file %s: %w", path, err)
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parsing config file %s: %w", path, err)
+	}
+
+	seen := make(map[string]bool, len(cfg.Targets))
+	for _, t := range cfg.Targets {
+		if t.Name == "" {
+			return nil, fmt.Errorf("target with dsn %q is missing a name", t.DSN)
+		}
+		if t.DSN == "" {
+			return nil, fmt.Errorf("target %q is missing a dsn", t.Name)
+		}
+		if seen[t.Name] {
+			return nil, fmt.Errorf("duplicate target name %q", t.Name)
+		}
+		seen[t.Name] = true
+	}
+
+	return &cfg, nil
+}
+
+// Lookup returns the target registered under name, if any.
+func (c *Config) Lookup(name string) (Target, bool) {
+	for _, t := range c.Targets {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return Target{}, false
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeConfig(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatalf("failed to write config fixture: %v", err)
+	}
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := writeConfig(t, `
+targets:
+  - name: primary
+    dsn: "postgres://primary/postgres"
+  - name: replica
+    dsn: "postgres://replica/postgres"
+    collectors: [stat_database]
+`)
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig returned an error: %v", err)
+	}
+
+	target, ok := cfg.Lookup("replica")
+	if !ok {
+		t.Fatal("expected to find target \"replica\"")
+	}
+	if target.DSN != "postgres://replica/postgres" {
+		t.Fatalf("unexpected dsn: %q", target.DSN)
+	}
+	if len(target.Collectors) != 1 || target.Collectors[0] != "stat_database" {
+		t.Fatalf("unexpected collectors: %v", target.Collectors)
+	}
+
+	if _, ok := cfg.Lookup("missing"); ok {
+		t.Fatal("expected Lookup of an unknown target to report not found")
+	}
+}
+
+func TestLoadConfigRejectsDuplicateNames(t *testing.T) {
+	path := writeConfig(t, `
+targets:
+  - name: primary
+    dsn: "postgres://a/postgres"
+  - name: primary
+    dsn: "postgres://b/postgres"
+`)
+
+	if _, err := LoadConfig(path); err == nil {
+		t.Fatal("expected an error for duplicate target names")
+	}
+}
+
+func TestLoadConfigRequiresNameAndDSN(t *testing.T) {
+	path := writeConfig(t, `
+targets:
+  - dsn: "postgres://a/postgres"
+`)
+
+	if _, err := LoadConfig(path); err == nil {
+		t.Fatal("expected an error for a target missing a name")
+	}
+
+	path = writeConfig(t, `
+targets:
+  - name: primary
+`)
+
+	if _, err := LoadConfig(path); err == nil {
+		t.Fatal("expected an error for a target missing a dsn")
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/dyson/postgres_exporter/collector"
+)
+
+var (
+	listenAddress = kingpin.Flag(
+		"web.listen-address",
+		"Address to listen on for web interface and telemetry.",
+	).Default(":9187").String()
+
+	metricsPath = kingpin.Flag(
+		"web.telemetry-path",
+		"Path under which to expose the single-target metrics driven by DATA_SOURCE_NAME.",
+	).Default("/metrics").String()
+
+	configFile = kingpin.Flag(
+		"config.file",
+		"Path to a YAML file listing Postgres targets to expose via /probe?target=<name>. If unset,"+
+			" only web.telemetry-path (driven by the DATA_SOURCE_NAME environment variable) is served.",
+	).Default("").String()
+
+	probeTimeout = kingpin.Flag(
+		"probe.timeout",
+		"Timeout applied to each /probe scrape.",
+	).Default("10s").Duration()
+)
+
+func main() {
+	kingpin.Parse()
+
+	pool := newDBPool(func(dsn string) (*sql.DB, error) {
+		return sql.Open("postgres", dsn)
+	})
+
+	if dsn := os.Getenv("DATA_SOURCE_NAME"); dsn != "" {
+		handler, err := metricsHandler(pool, dsn)
+		if err != nil {
+			log.Fatalf("setting up %s: %v", *metricsPath, err)
+		}
+		http.Handle(*metricsPath, handler)
+	}
+
+	if *configFile != "" {
+		cfg, err := LoadConfig(*configFile)
+		if err != nil {
+			log.Fatalf("loading config file %s: %v", *configFile, err)
+		}
+		http.HandleFunc("/probe", probeHandler(pool, cfg))
+	}
+
+	log.Printf("listening on %s", *listenAddress)
+	log.Fatal(http.ListenAndServe(*listenAddress, nil))
+}
+
+// metricsHandler serves the legacy single-target /metrics endpoint for the DSN configured
+// via the DATA_SOURCE_NAME environment variable.
+func metricsHandler(pool *dbPool, dsn string) (http.Handler, error) {
+	db, err := pool.get(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to DATA_SOURCE_NAME: %w", err)
+	}
+
+	exporter, err := collector.NewExporterCollector(context.Background(), db)
+	if err != nil {
+		return nil, fmt.Errorf("building collector: %w", err)
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(exporter)
+
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{}), nil
+}
+
+// probeHandler implements /probe?target=<name>, building a per-target ExporterCollector
+// against a connection cached (and shared across probes) by DSN, and labeling every metric
+// it produces with the target's name so one exporter process can monitor many instances.
+func probeHandler(pool *dbPool, cfg *Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("target")
+		if name == "" {
+			http.Error(w, "target parameter is required", http.StatusBadRequest)
+			return
+		}
+
+		target, ok := cfg.Lookup(name)
+		if !ok {
+			http.Error(w, fmt.Sprintf("unknown target %q", name), http.StatusNotFound)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), *probeTimeout)
+		defer cancel()
+
+		db, err := pool.get(target.DSN)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("connecting to target %q: %v", name, err), http.StatusInternalServerError)
+			return
+		}
+
+		exporter, err := collector.NewExporterCollector(ctx, db, target.Collectors...)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("building collector for target %q: %v", name, err), http.StatusInternalServerError)
+			return
+		}
+
+		registry := prometheus.NewRegistry()
+		labeled := prometheus.WrapRegistererWith(prometheus.Labels{"instance": name}, registry)
+		labeled.MustRegister(exporter)
+
+		promhttp.HandlerFor(registry, promhttp.HandlerOpts{}).ServeHTTP(w, r.WithContext(ctx))
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/alecthomas/kingpin/v2"
+)
+
+// statDatabaseRows returns a single pg_stat_database row matching the base (PG < 12) column
+// set, enough to satisfy statDatabaseCollector.Update without error.
+func statDatabaseRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{
+		"datname", "numbackends", "xact_commit", "xact_rollback", "blks_read", "blks_hit",
+		"tup_returned", "tup_fetched", "tup_inserted", "tup_updated", "tup_deleted",
+		"conflicts", "temp_files", "temp_bytes", "deadlocks", "stats_reset",
+	}).AddRow("app", 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, nil)
+}
+
+// TestProbeHandlerRestrictsToTargetCollectors is a regression test for a1c970d: probeHandler
+// must only run the collectors listed in a target's "collectors:", not every
+// globally-enabled collector.
+func TestProbeHandlerRestrictsToTargetCollectors(t *testing.T) {
+	// probeHandler reads *probeTimeout, which only gets its Default("10s") applied once
+	// kingpin.Parse runs; without this, the flag's zero value (0s) would make every probe
+	// time out immediately.
+	if _, err := kingpin.CommandLine.Parse(nil); err != nil {
+		t.Fatalf("failed to parse flags: %v", err)
+	}
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SHOW server_version_num").
+		WillReturnRows(sqlmock.NewRows([]string{"server_version_num"}).AddRow("90400"))
+	mock.ExpectQuery("SELECT .+ FROM pg_stat_database$").
+		WillReturnRows(statDatabaseRows())
+
+	pool := newDBPool(func(string) (*sql.DB, error) { return db, nil })
+	cfg := &Config{Targets: []Target{
+		{Name: "app", DSN: "mock", Collectors: []string{"stat_database"}},
+	}}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/probe?target=app", nil)
+	probeHandler(pool, cfg)(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	body := rec.Body.String()
+	if !strings.Contains(body, "postgres_stat_database_numbackends") {
+		t.Fatalf("expected stat_database's metrics in the response, got:\n%s", body)
+	}
+	if strings.Contains(body, "postgres_stat_database_conflicts_reason_total") {
+		t.Fatalf("expected stat_database_conflicts, which wasn't in the target's collectors list,"+
+			" to be absent from the response, got:\n%s", body)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unfulfilled sqlmock expectations: %v", err)
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"sync"
+
+	_ "github.com/lib/pq"
+)
+
+// dbOpener opens a *sql.DB for a DSN. In production this is sql.Open("postgres", dsn);
+// tests inject a fake so probeHandler/metricsHandler can be exercised against a sqlmock
+// database instead of a real Postgres connection.
+type dbOpener func(dsn string) (*sql.DB, error)
+
+// dbPool caches one *sql.DB connection pool per DSN, so /probe scrapes of the same target
+// reuse connections instead of dialing Postgres on every request.
+type dbPool struct {
+	mu    sync.Mutex
+	byDSN map[string]*sql.DB
+	open  dbOpener
+}
+
+func newDBPool(open dbOpener) *dbPool {
+	return &dbPool{byDSN: make(map[string]*sql.DB), open: open}
+}
+
+// get returns the *sql.DB for dsn, opening (but not connecting) one the first time it's seen.
+func (p *dbPool) get(dsn string) (*sql.DB, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if db, ok := p.byDSN[dsn]; ok {
+		return db, nil
+	}
+
+	db, err := p.open(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("opening connection: %w", err)
+	}
+
+	p.byDSN[dsn] = db
+	return db, nil
+}
@@ -0,0 +1,84 @@
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// namespace is the prefix shared by every metric exposed by this exporter.
+const namespace = "postgres"
+
+const (
+	defaultEnabled  = true
+	defaultDisabled = false
+)
+
+var (
+	factories      = make(map[string]func() (Collector, error))
+	collectorState = make(map[string]*bool)
+
+	// forcedCollectors records collectors whose --collector.<name> flag was explicitly
+	// set on the command line, so --collector.disable-defaults doesn't override them.
+	forcedCollectors = make(map[string]bool)
+)
+
+var disableDefaultCollectors = kingpin.Flag(
+	"collector.disable-defaults",
+	"Set all collectors to disabled by default.",
+).Default("false").Bool()
+
+// registerCollector registers a collector factory under name and, following the convention
+// established by node_exporter, wires up a --collector.<name> / --no-collector.<name>
+// kingpin flag that lets operators override whether it runs.
+func registerCollector(collector string, isDefaultEnabled bool, factory func() (Collector, error)) {
+	var helpDefaultState string
+	if isDefaultEnabled {
+		helpDefaultState = "enabled"
+	} else {
+		helpDefaultState = "disabled"
+	}
+
+	flagName := fmt.Sprintf("collector.%s", collector)
+	flagHelp := fmt.Sprintf("Enable the %s collector (default: %s).", collector, helpDefaultState)
+	defaultValue := fmt.Sprintf("%v", isDefaultEnabled)
+
+	flag := kingpin.Flag(flagName, flagHelp).
+		Default(defaultValue).
+		Action(collectorFlagAction(collector)).
+		Bool()
+
+	collectorState[collector] = flag
+	factories[collector] = factory
+}
+
+func collectorFlagAction(collector string) func(*kingpin.ParseContext) error {
+	return func(*kingpin.ParseContext) error {
+		forcedCollectors[collector] = true
+		return nil
+	}
+}
+
+// enabledCollectors returns, for every registered collector, whether it should run given
+// the current flag values and --collector.disable-defaults.
+func enabledCollectors() map[string]bool {
+	enabled := make(map[string]bool, len(collectorState))
+	for name, stateFlag := range collectorState {
+		state := *stateFlag
+		if *disableDefaultCollectors && !forcedCollectors[name] {
+			state = false
+		}
+		enabled[name] = state
+	}
+	return enabled
+}
+
+// Collector is the interface a collector has to implement.
+type Collector interface {
+	// Update sends the metrics collected by the Collector to the provided channel and
+	// returns an error if something went wrong.
+	Update(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error
+}
@@ -0,0 +1,64 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/alecthomas/kingpin/v2"
+)
+
+func TestCollectorFlagOverridesDefaultState(t *testing.T) {
+	enabledFlag, ok := collectorState["stat_database_conflicts"]
+	if !ok {
+		t.Fatal("stat_database_conflicts collector is not registered")
+	}
+
+	// kingpin only applies a flag's Default() once Parse runs, so the bool collectorState
+	// points at reads as the zero value (false) until then — parse an empty argument list
+	// first to establish the advertised default before asserting anything about it.
+	if _, err := kingpin.CommandLine.Parse(nil); err != nil {
+		t.Fatalf("failed to parse flags: %v", err)
+	}
+	if !*enabledFlag {
+		t.Fatal("expected stat_database_conflicts to be enabled by default")
+	}
+
+	if _, err := kingpin.CommandLine.Parse([]string{"--no-collector.stat_database_conflicts"}); err != nil {
+		t.Fatalf("failed to parse flags: %v", err)
+	}
+
+	if *enabledFlag {
+		t.Fatal("expected --no-collector.stat_database_conflicts to disable the collector")
+	}
+	if !forcedCollectors["stat_database_conflicts"] {
+		t.Fatal("expected stat_database_conflicts to be recorded as explicitly set on the command line")
+	}
+
+	if _, err := kingpin.CommandLine.Parse([]string{"--collector.stat_database_conflicts"}); err != nil {
+		t.Fatalf("failed to parse flags: %v", err)
+	}
+	if !*enabledFlag {
+		t.Fatal("expected --collector.stat_database_conflicts to re-enable the collector")
+	}
+}
+
+func TestDisableDefaultsLeavesForcedCollectorsEnabled(t *testing.T) {
+	if _, ok := collectorState["stat_database"]; !ok {
+		t.Fatal("stat_database collector is not registered")
+	}
+	if forcedCollectors["stat_database"] {
+		t.Fatal("test precondition violated: stat_database must not already be forced")
+	}
+
+	args := []string{"--collector.disable-defaults", "--collector.stat_database_conflicts"}
+	if _, err := kingpin.CommandLine.Parse(args); err != nil {
+		t.Fatalf("failed to parse flags: %v", err)
+	}
+
+	enabled := enabledCollectors()
+	if !enabled["stat_database_conflicts"] {
+		t.Fatal("expected stat_database_conflicts to stay enabled: it was explicitly requested")
+	}
+	if enabled["stat_database"] {
+		t.Fatal("expected stat_database to be disabled: --collector.disable-defaults with no explicit flag for it")
+	}
+}
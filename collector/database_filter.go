@@ -0,0 +1,71 @@
+package collector
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/alecthomas/kingpin/v2"
+)
+
+// databaseFilter implements an include/exclude regexp filter on datname, meant to be shared
+// by any per-database collector that wants to keep datname cardinality under control on
+// clusters with many (often transient) databases. It's applied twice: pushed down into the
+// collector's SQL query via whereClause, so Postgres does the filtering and we don't pay to
+// scan rows we'd throw away, and again in Go via matches, as a safety net for any row that
+// slips through.
+type databaseFilter struct {
+	include *string
+	exclude *string
+}
+
+// newDatabaseFilterFlags registers --collector.<collector>.databases-include and
+// --collector.<collector>.databases-exclude regexp flags and returns a filter backed by
+// their values.
+func newDatabaseFilterFlags(collector string) *databaseFilter {
+	include := kingpin.Flag(
+		fmt.Sprintf("collector.%s.databases-include", collector),
+		"Regexp of databases to include. Empty matches all databases.",
+	).Default("").String()
+
+	exclude := kingpin.Flag(
+		fmt.Sprintf("collector.%s.databases-exclude", collector),
+		"Regexp of databases to exclude. Applied after databases-include.",
+	).Default("").String()
+
+	return &databaseFilter{include: include, exclude: exclude}
+}
+
+// whereClause returns a SQL boolean expression filtering on datname ("" if neither flag is
+// set), along with the query args it references, starting at placeholder $argOffset.
+func (f *databaseFilter) whereClause(argOffset int) (string, []interface{}) {
+	var conds []string
+	var args []interface{}
+
+	if *f.include != "" {
+		conds = append(conds, fmt.Sprintf("datname ~ $%d", argOffset+len(args)))
+		args = append(args, *f.include)
+	}
+	if *f.exclude != "" {
+		conds = append(conds, fmt.Sprintf("datname !~ $%d", argOffset+len(args)))
+		args = append(args, *f.exclude)
+	}
+
+	return strings.Join(conds, " AND "), args
+}
+
+// matches re-checks datname against the same include/exclude regexps, as a safety net for
+// rows the SQL-side filter didn't catch.
+func (f *databaseFilter) matches(datname string) bool {
+	if *f.include != "" {
+		if ok, err := regexp.MatchString(*f.include, datname); err != nil || !ok {
+			return false
+		}
+	}
+	if *f.exclude != "" {
+		if ok, err := regexp.MatchString(*f.exclude, datname); err == nil && ok {
+			return false
+		}
+	}
+	return true
+}
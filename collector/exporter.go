@@ -0,0 +1,148 @@
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	// scrapeDurationDesc is deliberately a Gauge, not a Histogram/Summary: probeHandler builds
+	// a fresh ExporterCollector (and Registry) for every /probe request, so there's no
+	// persistent collector state across those requests for a Histogram to accumulate
+	// observations into — each scrape only ever has the one reading a Gauge already captures. This mirrors
+	// node_exporter's own collector_duration_seconds, which is a Gauge for the same reason.
+	scrapeDurationDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "scrape", "collector_duration_seconds"),
+		"postgres_exporter: Duration of a collector scrape.",
+		[]string{"collector"},
+		nil,
+	)
+	scrapeSuccessDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "scrape", "collector_success"),
+		"postgres_exporter: Whether a collector succeeded.",
+		[]string{"collector"},
+		nil,
+	)
+	scrapeLastErrorDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "scrape", "collector_last_error"),
+		"postgres_exporter: Unix timestamp of a collector's last scrape error, 0 if it has never failed.",
+		[]string{"collector"},
+		nil,
+	)
+)
+
+// ExporterCollector implements prometheus.Collector, running every Collector enabled via
+// its --collector.<name> flag (or, when NewExporterCollector was given filters, exactly the
+// named ones) against a single *sql.DB and forwarding the metrics it
+// produces to Prometheus. It also records, per collector, how long the scrape took,
+// whether it succeeded, and when it last failed.
+type ExporterCollector struct {
+	ctx        context.Context
+	db         *sql.DB
+	collectors map[string]Collector
+
+	mu        sync.Mutex
+	lastError map[string]float64
+}
+
+// NewExporterCollector builds an ExporterCollector from the collectors currently enabled,
+// instantiating one Collector per registered factory whose flag evaluates to true. ctx is
+// threaded through to every collector's Update call, so a caller scraping db on a deadline
+// (e.g. a /probe request with a per-target timeout) can enforce it.
+//
+// filters, if non-empty, restricts the Collectors instantiated to exactly those names
+// (e.g. a target's own "collectors:" list in a multi-target config), ignoring the global
+// --collector.<name> flag state. An unknown name in filters is an error.
+func NewExporterCollector(ctx context.Context, db *sql.DB, filters ...string) (*ExporterCollector, error) {
+	selected, err := selectCollectors(filters)
+	if err != nil {
+		return nil, err
+	}
+
+	collectors := make(map[string]Collector)
+	for name, enabled := range selected {
+		if !enabled {
+			continue
+		}
+
+		c, err := factories[name]()
+		if err != nil {
+			return nil, err
+		}
+		collectors[name] = c
+	}
+
+	return &ExporterCollector{
+		ctx:        ctx,
+		db:         db,
+		collectors: collectors,
+		lastError:  make(map[string]float64),
+	}, nil
+}
+
+// selectCollectors returns, for every registered collector, whether it should run. With no
+// filters it defers to enabledCollectors (the global --collector.<name> flags). With
+// filters, exactly those names run, regardless of flag state.
+func selectCollectors(filters []string) (map[string]bool, error) {
+	if len(filters) == 0 {
+		return enabledCollectors(), nil
+	}
+
+	selected := make(map[string]bool, len(filters))
+	for _, name := range filters {
+		if _, ok := factories[name]; !ok {
+			return nil, fmt.Errorf("unknown collector: %s", name)
+		}
+		selected[name] = true
+	}
+	return selected, nil
+}
+
+// Describe implements prometheus.Collector. Only the scrape-timing descriptors are sent
+// here; the descriptors of the metrics produced by the individual Collectors are not.
+func (e *ExporterCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- scrapeDurationDesc
+	ch <- scrapeSuccessDesc
+	ch <- scrapeLastErrorDesc
+}
+
+// Collect implements prometheus.Collector.
+func (e *ExporterCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx := e.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	for name, c := range e.collectors {
+		e.collectOne(ctx, name, c, ch)
+	}
+}
+
+func (e *ExporterCollector) collectOne(ctx context.Context, name string, c Collector, ch chan<- prometheus.Metric) {
+	start := time.Now()
+	err := c.Update(ctx, e.db, ch)
+	duration := time.Since(start).Seconds()
+
+	success := 1.0
+	if err != nil {
+		success = 0
+		log.Printf("error collecting metrics from collector %s: %v", name, err)
+
+		e.mu.Lock()
+		e.lastError[name] = float64(time.Now().Unix())
+		e.mu.Unlock()
+	}
+
+	e.mu.Lock()
+	lastError := e.lastError[name]
+	e.mu.Unlock()
+
+	ch <- prometheus.MustNewConstMetric(scrapeDurationDesc, prometheus.GaugeValue, duration, name)
+	ch <- prometheus.MustNewConstMetric(scrapeSuccessDesc, prometheus.GaugeValue, success, name)
+	ch <- prometheus.MustNewConstMetric(scrapeLastErrorDesc, prometheus.GaugeValue, lastError, name)
+}
@@ -0,0 +1,108 @@
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// stubCollector lets tests control exactly what Update does without touching a real
+// database, exercising the scrape-timing wrapper the same way stat_database's Update would.
+type stubCollector struct {
+	err error
+}
+
+func (s stubCollector) Update(_ context.Context, _ *sql.DB, _ chan<- prometheus.Metric) error {
+	return s.err
+}
+
+// collectGauge drains ch and returns, for the metric built from desc, a map from the
+// "collector" label value to the gauge value.
+func collectGauge(t *testing.T, ch <-chan prometheus.Metric, desc *prometheus.Desc) map[string]float64 {
+	t.Helper()
+	values := make(map[string]float64)
+	for m := range ch {
+		if m.Desc() != desc {
+			continue
+		}
+		var pb dto.Metric
+		if err := m.Write(&pb); err != nil {
+			t.Fatalf("failed to write metric: %v", err)
+		}
+		for _, l := range pb.Label {
+			if l.GetName() == "collector" {
+				values[l.GetValue()] = pb.GetGauge().GetValue()
+			}
+		}
+	}
+	return values
+}
+
+func TestCollectOneRecordsSuccess(t *testing.T) {
+	e := &ExporterCollector{lastError: make(map[string]float64)}
+
+	ch := make(chan prometheus.Metric, 16)
+	e.collectOne(context.Background(), "stat_database", stubCollector{}, ch)
+	close(ch)
+
+	success := collectGauge(t, ch, scrapeSuccessDesc)
+	if success["stat_database"] != 1 {
+		t.Fatalf("expected stat_database to report success=1, got %v", success["stat_database"])
+	}
+	if e.lastError["stat_database"] != 0 {
+		t.Fatalf("expected no last-error timestamp to be recorded on success, got %v", e.lastError["stat_database"])
+	}
+}
+
+func TestCollectOneRecordsFailure(t *testing.T) {
+	e := &ExporterCollector{lastError: make(map[string]float64)}
+
+	ch := make(chan prometheus.Metric, 16)
+	e.collectOne(context.Background(), "stat_database", stubCollector{err: errors.New("boom")}, ch)
+	close(ch)
+
+	success := collectGauge(t, ch, scrapeSuccessDesc)
+	if success["stat_database"] != 0 {
+		t.Fatalf("expected stat_database to report success=0, got %v", success["stat_database"])
+	}
+	if e.lastError["stat_database"] == 0 {
+		t.Fatal("expected a last-error timestamp to be recorded on failure")
+	}
+}
+
+func TestSelectCollectorsWithNoFiltersUsesFlagState(t *testing.T) {
+	selected, err := selectCollectors(nil)
+	if err != nil {
+		t.Fatalf("selectCollectors returned an error: %v", err)
+	}
+	if _, ok := selected["stat_database"]; !ok {
+		t.Fatal("expected stat_database to be present in the globally-enabled set")
+	}
+}
+
+func TestSelectCollectorsWithFiltersRestrictsToThem(t *testing.T) {
+	selected, err := selectCollectors([]string{"stat_database"})
+	if err != nil {
+		t.Fatalf("selectCollectors returned an error: %v", err)
+	}
+
+	if !selected["stat_database"] {
+		t.Fatal("expected stat_database to be selected")
+	}
+	if selected["stat_database_conflicts"] {
+		t.Fatal("expected stat_database_conflicts, which wasn't in the filter list, to be absent")
+	}
+	if len(selected) != 1 {
+		t.Fatalf("expected exactly one selected collector, got %v", selected)
+	}
+}
+
+func TestSelectCollectorsRejectsUnknownName(t *testing.T) {
+	if _, err := selectCollectors([]string{"does_not_exist"}); err == nil {
+		t.Fatal("expected an error for an unregistered collector name")
+	}
+}
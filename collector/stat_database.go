@@ -3,6 +3,10 @@ package collector
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/prometheus/client_golang/prometheus"
 )
@@ -10,13 +14,80 @@ import (
 const (
 	// Subsystem
 	statDatabaseSubsystem = "stat_database"
-	// Scrape query
-	statDatabaseQuery = `SELECT datname, numbackends, tup_returned, tup_fetched, tup_inserted, tup_updated, tup_deleted,
-							   xact_commit, xact_rollback, blks_read, blks_hit, conflicts, deadlocks,
-							   temp_files, temp_bytes
-						FROM pg_stat_database`
+
+	// server_version_num thresholds at which pg_stat_database grew new columns.
+	pgVersion12 = 120000
+	pgVersion14 = 140000
+)
+
+// statDatabaseColumns are the pg_stat_database columns available on every supported
+// PostgreSQL version (9.4+).
+var statDatabaseColumns = []string{
+	"datname", "numbackends", "xact_commit", "xact_rollback", "blks_read", "blks_hit",
+	"tup_returned", "tup_fetched", "tup_inserted", "tup_updated", "tup_deleted",
+	"conflicts", "temp_files", "temp_bytes", "deadlocks", "stats_reset",
+}
+
+// statDatabaseChecksumColumns were added in PostgreSQL 12.
+var statDatabaseChecksumColumns = []string{
+	"checksum_failures", "checksum_last_failure", "blk_read_time", "blk_write_time",
+}
+
+// statDatabaseSessionColumns were added in PostgreSQL 14.
+var statDatabaseSessionColumns = []string{
+	"session_time", "active_time", "idle_in_transaction_time",
+	"sessions", "sessions_abandoned", "sessions_fatal", "sessions_killed",
+}
+
+// statDatabaseQuery builds the pg_stat_database SELECT for a given server_version_num,
+// adding columns as they became available in newer PostgreSQL releases, and filtered by
+// where (a datname boolean expression from databaseFilter.whereClause, or "" for no filter).
+func statDatabaseQuery(versionNum int, where string) string {
+	columns := append([]string{}, statDatabaseColumns...)
+
+	if versionNum >= pgVersion12 {
+		columns = append(columns, statDatabaseChecksumColumns...)
+	}
+	if versionNum >= pgVersion14 {
+		columns = append(columns, statDatabaseSessionColumns...)
+	}
+
+	query := "SELECT " + strings.Join(columns, ", ") + " FROM pg_stat_database"
+	if where != "" {
+		query += " WHERE " + where
+	}
+	return query
+}
+
+var (
+	serverVersionCacheMu sync.Mutex
+	serverVersionCache   = make(map[*sql.DB]int)
 )
 
+// getServerVersionNum returns the connected server's server_version_num, querying it once
+// per *sql.DB and caching the result for the lifetime of the connection pool.
+func getServerVersionNum(ctx context.Context, db *sql.DB) (int, error) {
+	serverVersionCacheMu.Lock()
+	defer serverVersionCacheMu.Unlock()
+
+	if v, ok := serverVersionCache[db]; ok {
+		return v, nil
+	}
+
+	var raw string
+	if err := db.QueryRowContext(ctx, "SHOW server_version_num").Scan(&raw); err != nil {
+		return 0, err
+	}
+
+	v, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, fmt.Errorf("invalid server_version_num %q: %w", raw, err)
+	}
+
+	serverVersionCache[db] = v
+	return v, nil
+}
+
 type statDatabaseCollector struct {
 	numbackends  *prometheus.Desc
 	tupReturned  *prometheus.Desc
@@ -32,8 +103,32 @@ type statDatabaseCollector struct {
 	deadlocks    *prometheus.Desc
 	tempFiles    *prometheus.Desc
 	tempBytes    *prometheus.Desc
+	statsReset   *prometheus.Desc
+
+	// Added in PostgreSQL 12.
+	checksumFailures    *prometheus.Desc
+	checksumLastFailure *prometheus.Desc
+	blkReadTime         *prometheus.Desc
+	blkWriteTime        *prometheus.Desc
+
+	// Added in PostgreSQL 14.
+	sessionTime           *prometheus.Desc
+	activeTime            *prometheus.Desc
+	idleInTransactionTime *prometheus.Desc
+	sessions              *prometheus.Desc
+	sessionsAbandoned     *prometheus.Desc
+	sessionsFatal         *prometheus.Desc
+	sessionsKilled        *prometheus.Desc
+
+	filter *databaseFilter
 }
 
+// statDatabaseFilter's flags must be registered with kingpin exactly once, at package load
+// time, before main() calls kingpin.Parse() — not from inside NewStatDatabaseCollector,
+// which factories[name]() calls on every ExporterCollector construction (once per /probe
+// request in the multi-target path).
+var statDatabaseFilter = newDatabaseFilterFlags("stat_database")
+
 func init() {
 	registerCollector("stat_database", defaultEnabled, NewStatDatabaseCollector)
 }
@@ -45,9 +140,13 @@ func init() {
 // individual-row terms. It also tracks the total number of rows in each table, and information about vacuum
 // and analyze actions for each table. It can also count calls to user-defined functions and the total time
 // spent in each one.
-// https://www.postgresql.org/docs/9.4/static/monitoring-stats.html#PG-STAT-DATABASE-VIEW
+//
+// Not every column is available on every PostgreSQL version: columns added after 9.4 are only queried (and
+// only exposed) when Update detects, via SHOW server_version_num, that the connected server is new enough.
+// https://www.postgresql.org/docs/current/monitoring-stats.html#MONITORING-PG-STAT-DATABASE-VIEW
 func NewStatDatabaseCollector() (Collector, error) {
 	return &statDatabaseCollector{
+		filter: statDatabaseFilter,
 		numbackends: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, statDatabaseSubsystem, "numbackends"),
 			"Number of backends currently connected to this database. This is the only column in this"+
@@ -139,72 +238,176 @@ func NewStatDatabaseCollector() (Collector, error) {
 			[]string{"datname"},
 			nil,
 		),
+		statsReset: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, statDatabaseSubsystem, "stats_reset_time_seconds"),
+			"Time at which these statistics were last reset, as a Unix timestamp",
+			[]string{"datname"},
+			nil,
+		),
+		checksumFailures: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, statDatabaseSubsystem, "checksum_failures_total"),
+			"Number of data page checksum failures detected in this database (or on a shared object), if data"+
+				" checksums are enabled. Available from PostgreSQL 12.",
+			[]string{"datname"},
+			nil,
+		),
+		checksumLastFailure: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, statDatabaseSubsystem, "checksum_last_failure_time_seconds"),
+			"Time at which the last data page checksum failure was detected in this database (or on a shared"+
+				" object), as a Unix timestamp. Available from PostgreSQL 12.",
+			[]string{"datname"},
+			nil,
+		),
+		blkReadTime: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, statDatabaseSubsystem, "blk_read_time_seconds_total"),
+			"Time spent reading data file blocks by backends in this database, in seconds. Only non-zero when"+
+				" track_io_timing is enabled. Available from PostgreSQL 12.",
+			[]string{"datname"},
+			nil,
+		),
+		blkWriteTime: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, statDatabaseSubsystem, "blk_write_time_seconds_total"),
+			"Time spent writing data file blocks by backends in this database, in seconds. Only non-zero when"+
+				" track_io_timing is enabled. Available from PostgreSQL 12.",
+			[]string{"datname"},
+			nil,
+		),
+		sessionTime: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, statDatabaseSubsystem, "session_time_seconds_total"),
+			"Time spent by database sessions in this database, in seconds. Available from PostgreSQL 14.",
+			[]string{"datname"},
+			nil,
+		),
+		activeTime: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, statDatabaseSubsystem, "active_time_seconds_total"),
+			"Time spent executing SQL statements in this database, in seconds. Available from PostgreSQL 14.",
+			[]string{"datname"},
+			nil,
+		),
+		idleInTransactionTime: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, statDatabaseSubsystem, "idle_in_transaction_time_seconds_total"),
+			"Time spent idling while in a transaction in this database, in seconds. Available from PostgreSQL 14.",
+			[]string{"datname"},
+			nil,
+		),
+		sessions: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, statDatabaseSubsystem, "sessions_total"),
+			"Total number of sessions established to this database. Available from PostgreSQL 14.",
+			[]string{"datname"},
+			nil,
+		),
+		sessionsAbandoned: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, statDatabaseSubsystem, "sessions_abandoned_total"),
+			"Number of database sessions to this database that were terminated because connection to the"+
+				" client was lost. Available from PostgreSQL 14.",
+			[]string{"datname"},
+			nil,
+		),
+		sessionsFatal: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, statDatabaseSubsystem, "sessions_fatal_total"),
+			"Number of database sessions to this database that were terminated by fatal errors. Available"+
+				" from PostgreSQL 14.",
+			[]string{"datname"},
+			nil,
+		),
+		sessionsKilled: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, statDatabaseSubsystem, "sessions_killed_total"),
+			"Number of database sessions to this database that were terminated by an operator killing the"+
+				" backend. Available from PostgreSQL 14.",
+			[]string{"datname"},
+			nil,
+		),
 	}, nil
 }
 
 func (c *statDatabaseCollector) Update(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
-	rows, err := db.QueryContext(ctx, statDatabaseQuery)
+	versionNum, err := getServerVersionNum(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	hasChecksums := versionNum >= pgVersion12
+	hasSessions := versionNum >= pgVersion14
+
+	where, args := c.filter.whereClause(1)
+	rows, err := db.QueryContext(ctx, statDatabaseQuery(versionNum, where), args...)
 	if err != nil {
 		return err
 	}
 	defer rows.Close()
 
-	var datname string
-	var numbackends, tupReturned, tupFetched, tupInserted, tupUpdated, tupDeleted, xactCommit, xactRollback,
-		blksRead, blksHit, conflicts, deadlocks, tempFiles, tempBytes float64
+	var (
+		datname                                                      string
+		numbackends, xactCommit, xactRollback, blksRead, blksHit     float64
+		tupReturned, tupFetched, tupInserted, tupUpdated, tupDeleted float64
+		conflicts, tempFiles, tempBytes, deadlocks                   float64
+		statsReset                                                   sql.NullTime
+		checksumFailures, blkReadTime, blkWriteTime                  float64
+		checksumLastFailure                                          sql.NullTime
+		sessionTime, activeTime, idleInTransactionTime               float64
+		sessions, sessionsAbandoned, sessionsFatal, sessionsKilled   float64
+	)
+
 	for rows.Next() {
-		if err := rows.Scan(&datname,
-			&numbackends,
-			&tupReturned,
-			&tupFetched,
-			&tupInserted,
-			&tupUpdated,
-			&tupDeleted,
-			&xactCommit,
-			&xactRollback,
-			&blksRead,
-			&blksHit,
-			&conflicts,
-			&deadlocks,
-			&tempFiles,
-			&tempBytes); err != nil {
+		dest := []interface{}{
+			&datname, &numbackends, &xactCommit, &xactRollback, &blksRead, &blksHit,
+			&tupReturned, &tupFetched, &tupInserted, &tupUpdated, &tupDeleted,
+			&conflicts, &tempFiles, &tempBytes, &deadlocks, &statsReset,
+		}
+		if hasChecksums {
+			dest = append(dest, &checksumFailures, &checksumLastFailure, &blkReadTime, &blkWriteTime)
+		}
+		if hasSessions {
+			dest = append(dest, &sessionTime, &activeTime, &idleInTransactionTime,
+				&sessions, &sessionsAbandoned, &sessionsFatal, &sessionsKilled)
+		}
+
+		if err := rows.Scan(dest...); err != nil {
 			return err
 		}
 
-		// postgres_stat_database_numbackends
+		if !c.filter.matches(datname) {
+			continue
+		}
+
 		ch <- prometheus.MustNewConstMetric(c.numbackends, prometheus.GaugeValue, numbackends, datname)
-		// postgres_stat_database_tup_returned_total
-		ch <- prometheus.MustNewConstMetric(c.tupReturned, prometheus.CounterValue, tupReturned, datname)
-		// postgres_stat_database_tup_fetched_total
-		ch <- prometheus.MustNewConstMetric(c.tupFetched, prometheus.CounterValue, tupFetched, datname)
-		// postgres_stat_database_tup_inserted_total
-		ch <- prometheus.MustNewConstMetric(c.tupInserted, prometheus.CounterValue, tupInserted, datname)
-		// postgres_stat_database_tup_updated_total
-		ch <- prometheus.MustNewConstMetric(c.tupUpdated, prometheus.CounterValue, tupUpdated, datname)
-		// postgres_stat_database_tup_deleted_total
-		ch <- prometheus.MustNewConstMetric(c.tupDeleted, prometheus.CounterValue, tupUpdated, datname)
-		// postgres_stat_database_xact_commit_total
 		ch <- prometheus.MustNewConstMetric(c.xactCommit, prometheus.CounterValue, xactCommit, datname)
-		// postgres_stat_database_tup_xact_rollback_total
 		ch <- prometheus.MustNewConstMetric(c.xactRollback, prometheus.CounterValue, xactRollback, datname)
-		// postgres_stat_database_blks_read_total
 		ch <- prometheus.MustNewConstMetric(c.blksRead, prometheus.CounterValue, blksRead, datname)
-		// postgres_stat_database_blks_hit_total
 		ch <- prometheus.MustNewConstMetric(c.blksHit, prometheus.CounterValue, blksHit, datname)
-		// postgres_stat_database_conflicts_total
+		ch <- prometheus.MustNewConstMetric(c.tupReturned, prometheus.CounterValue, tupReturned, datname)
+		ch <- prometheus.MustNewConstMetric(c.tupFetched, prometheus.CounterValue, tupFetched, datname)
+		ch <- prometheus.MustNewConstMetric(c.tupInserted, prometheus.CounterValue, tupInserted, datname)
+		ch <- prometheus.MustNewConstMetric(c.tupUpdated, prometheus.CounterValue, tupUpdated, datname)
+		ch <- prometheus.MustNewConstMetric(c.tupDeleted, prometheus.CounterValue, tupDeleted, datname)
 		ch <- prometheus.MustNewConstMetric(c.conflicts, prometheus.CounterValue, conflicts, datname)
-		// postgres_stat_database_deadlocks_total
-		ch <- prometheus.MustNewConstMetric(c.deadlocks, prometheus.CounterValue, deadlocks, datname)
-		// postgres_stat_database_temp_files_total
 		ch <- prometheus.MustNewConstMetric(c.tempFiles, prometheus.CounterValue, tempFiles, datname)
-		// postgres_stat_database_temp_bytes_total
 		ch <- prometheus.MustNewConstMetric(c.tempBytes, prometheus.CounterValue, tempBytes, datname)
-	}
+		ch <- prometheus.MustNewConstMetric(c.deadlocks, prometheus.CounterValue, deadlocks, datname)
+		if statsReset.Valid {
+			ch <- prometheus.MustNewConstMetric(c.statsReset, prometheus.GaugeValue, float64(statsReset.Time.Unix()), datname)
+		}
 
-	err = rows.Err()
-	if err != nil {
-		return err
+		if hasChecksums {
+			ch <- prometheus.MustNewConstMetric(c.checksumFailures, prometheus.CounterValue, checksumFailures, datname)
+			if checksumLastFailure.Valid {
+				ch <- prometheus.MustNewConstMetric(c.checksumLastFailure, prometheus.GaugeValue,
+					float64(checksumLastFailure.Time.Unix()), datname)
+			}
+			ch <- prometheus.MustNewConstMetric(c.blkReadTime, prometheus.CounterValue, blkReadTime/1000, datname)
+			ch <- prometheus.MustNewConstMetric(c.blkWriteTime, prometheus.CounterValue, blkWriteTime/1000, datname)
+		}
+
+		if hasSessions {
+			ch <- prometheus.MustNewConstMetric(c.sessionTime, prometheus.CounterValue, sessionTime/1000, datname)
+			ch <- prometheus.MustNewConstMetric(c.activeTime, prometheus.CounterValue, activeTime/1000, datname)
+			ch <- prometheus.MustNewConstMetric(c.idleInTransactionTime, prometheus.CounterValue, idleInTransactionTime/1000, datname)
+			ch <- prometheus.MustNewConstMetric(c.sessions, prometheus.CounterValue, sessions, datname)
+			ch <- prometheus.MustNewConstMetric(c.sessionsAbandoned, prometheus.CounterValue, sessionsAbandoned, datname)
+			ch <- prometheus.MustNewConstMetric(c.sessionsFatal, prometheus.CounterValue, sessionsFatal, datname)
+			ch <- prometheus.MustNewConstMetric(c.sessionsKilled, prometheus.CounterValue, sessionsKilled, datname)
+		}
 	}
 
-	return nil
+	return rows.Err()
 }
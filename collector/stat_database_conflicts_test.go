@@ -0,0 +1,85 @@
+package collector
+
+import (
+	"context"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestStatDatabaseConflictsCollector(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{
+		"datname", "confl_tablespace", "confl_lock", "confl_snapshot", "confl_bufferpin", "confl_deadlock",
+	}).
+		AddRow("app", 1, 2, 3, 4, 5)
+
+	mock.ExpectQuery("SELECT .+ FROM pg_stat_database_conflicts").
+		WillReturnRows(rows)
+
+	collector, err := NewStatDatabaseConflictsCollector()
+	if err != nil {
+		t.Fatalf("NewStatDatabaseConflictsCollector returned an error: %v", err)
+	}
+	c := collector.(*statDatabaseConflictsCollector)
+
+	ch := make(chan prometheus.Metric, 16)
+	if err := c.Update(context.Background(), db, ch); err != nil {
+		t.Fatalf("Update returned an error: %v", err)
+	}
+	close(ch)
+
+	byReason := metricsByReason(t, ch)
+	want := map[string]float64{
+		"tablespace": 1,
+		"lock":       2,
+		"snapshot":   3,
+		"bufferpin":  4,
+		"deadlock":   5,
+	}
+	for reason, wantValue := range want {
+		if got := byReason["app"][reason]; got != wantValue {
+			t.Fatalf("reason %q: expected %v, got %v", reason, wantValue, got)
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unfulfilled sqlmock expectations: %v", err)
+	}
+}
+
+// metricsByReason drains ch and returns, for each datname, the reason_total value keyed
+// by its "reason" label.
+func metricsByReason(t *testing.T, ch <-chan prometheus.Metric) map[string]map[string]float64 {
+	t.Helper()
+	byDatname := make(map[string]map[string]float64)
+	for m := range ch {
+		var pb dto.Metric
+		if err := m.Write(&pb); err != nil {
+			t.Fatalf("failed to write metric: %v", err)
+		}
+
+		var datname, reason string
+		for _, l := range pb.Label {
+			switch l.GetName() {
+			case "datname":
+				datname = l.GetValue()
+			case "reason":
+				reason = l.GetValue()
+			}
+		}
+
+		if byDatname[datname] == nil {
+			byDatname[datname] = make(map[string]float64)
+		}
+		byDatname[datname][reason] = pb.GetCounter().GetValue()
+	}
+	return byDatname
+}
@@ -0,0 +1,191 @@
+package collector
+
+import (
+	"context"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// newTestStatDatabaseCollector builds a statDatabaseCollector via the real constructor (so
+// its Descs match production) with filter swapped out for one under the test's control,
+// independent of the package-level, flag-bound statDatabaseFilter.
+func newTestStatDatabaseCollector(t *testing.T, filter *databaseFilter) *statDatabaseCollector {
+	t.Helper()
+	c, err := NewStatDatabaseCollector()
+	if err != nil {
+		t.Fatalf("NewStatDatabaseCollector returned an error: %v", err)
+	}
+	sc := c.(*statDatabaseCollector)
+	sc.filter = filter
+	return sc
+}
+
+func noopFilter() *databaseFilter {
+	return &databaseFilter{include: strPtr(""), exclude: strPtr("")}
+}
+
+// metricsByName drains ch and returns, for every metric seen, its Desc's String() form to
+// (datname -> value).
+func metricsByName(t *testing.T, ch <-chan prometheus.Metric) map[string]map[string]float64 {
+	t.Helper()
+	byName := make(map[string]map[string]float64)
+	for m := range ch {
+		var pb dto.Metric
+		if err := m.Write(&pb); err != nil {
+			t.Fatalf("failed to write metric: %v", err)
+		}
+
+		var datname string
+		for _, l := range pb.Label {
+			if l.GetName() == "datname" {
+				datname = l.GetValue()
+			}
+		}
+
+		value := pb.GetCounter().GetValue()
+		if pb.Gauge != nil {
+			value = pb.GetGauge().GetValue()
+		}
+
+		name := m.Desc().String()
+		if byName[name] == nil {
+			byName[name] = make(map[string]float64)
+		}
+		byName[name][datname] = value
+	}
+	return byName
+}
+
+func TestStatDatabaseCollectorFiltersDatabases(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SHOW server_version_num").
+		WillReturnRows(sqlmock.NewRows([]string{"server_version_num"}).AddRow("90400"))
+
+	rows := sqlmock.NewRows([]string{
+		"datname", "numbackends", "xact_commit", "xact_rollback", "blks_read", "blks_hit",
+		"tup_returned", "tup_fetched", "tup_inserted", "tup_updated", "tup_deleted",
+		"conflicts", "temp_files", "temp_bytes", "deadlocks", "stats_reset",
+	}).
+		AddRow("prod_app", 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, nil).
+		AddRow("prod_reports", 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, nil).
+		AddRow("ci_ephemeral_42", 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, nil)
+
+	mock.ExpectQuery("SELECT .+ FROM pg_stat_database WHERE datname \\~ \\$1").
+		WithArgs("^prod_").
+		WillReturnRows(rows)
+
+	c := newTestStatDatabaseCollector(t, &databaseFilter{include: strPtr("^prod_"), exclude: strPtr("")})
+
+	ch := make(chan prometheus.Metric, 64)
+	if err := c.Update(context.Background(), db, ch); err != nil {
+		t.Fatalf("Update returned an error: %v", err)
+	}
+	close(ch)
+
+	seen := map[string]bool{}
+	for _, byDatname := range metricsByName(t, ch) {
+		for datname := range byDatname {
+			seen[datname] = true
+		}
+	}
+
+	if !seen["prod_app"] || !seen["prod_reports"] {
+		t.Fatalf("expected prod_* databases to be present, got %v", seen)
+	}
+	if seen["ci_ephemeral_42"] {
+		t.Fatalf("expected ci_ephemeral_42 to be filtered out by the Go-side safety net, got %v", seen)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unfulfilled sqlmock expectations: %v", err)
+	}
+}
+
+// TestStatDatabaseCollectorQueriesVersionGatedColumnsOnPG14 exercises the columns and
+// branches that only exist on PostgreSQL 12+ / 14+: the query includes the checksum and
+// session columns, and their millisecond-valued columns are converted to seconds.
+func TestStatDatabaseCollectorQueriesVersionGatedColumnsOnPG14(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SHOW server_version_num").
+		WillReturnRows(sqlmock.NewRows([]string{"server_version_num"}).AddRow("140005"))
+
+	columns := []string{
+		"datname", "numbackends", "xact_commit", "xact_rollback", "blks_read", "blks_hit",
+		"tup_returned", "tup_fetched", "tup_inserted", "tup_updated", "tup_deleted",
+		"conflicts", "temp_files", "temp_bytes", "deadlocks", "stats_reset",
+		"checksum_failures", "checksum_last_failure", "blk_read_time", "blk_write_time",
+		"session_time", "active_time", "idle_in_transaction_time",
+		"sessions", "sessions_abandoned", "sessions_fatal", "sessions_killed",
+	}
+	rows := sqlmock.NewRows(columns).
+		AddRow("app", 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, nil,
+			2 /* checksum_failures */, nil, 5000.0 /* blk_read_time ms */, 3000.0, /* blk_write_time ms */
+			60000.0 /* session_time ms */, 40000.0 /* active_time ms */, 1000.0, /* idle_in_transaction_time ms */
+			9, 1, 2, 3)
+
+	mock.ExpectQuery("SELECT .+checksum_failures.+session_time.+ FROM pg_stat_database").
+		WillReturnRows(rows)
+
+	c := newTestStatDatabaseCollector(t, noopFilter())
+
+	ch := make(chan prometheus.Metric, 64)
+	if err := c.Update(context.Background(), db, ch); err != nil {
+		t.Fatalf("Update returned an error: %v", err)
+	}
+	close(ch)
+
+	byName := metricsByName(t, ch)
+
+	if got := byName[c.checksumFailures.String()]["app"]; got != 2 {
+		t.Fatalf("expected checksum_failures=2, got %v", got)
+	}
+	if got := byName[c.blkReadTime.String()]["app"]; got != 5 {
+		t.Fatalf("expected blk_read_time converted from ms to 5s, got %v", got)
+	}
+	if got := byName[c.blkWriteTime.String()]["app"]; got != 3 {
+		t.Fatalf("expected blk_write_time converted from ms to 3s, got %v", got)
+	}
+	if got := byName[c.sessionTime.String()]["app"]; got != 60 {
+		t.Fatalf("expected session_time converted from ms to 60s, got %v", got)
+	}
+	if got := byName[c.activeTime.String()]["app"]; got != 40 {
+		t.Fatalf("expected active_time converted from ms to 40s, got %v", got)
+	}
+	if got := byName[c.idleInTransactionTime.String()]["app"]; got != 1 {
+		t.Fatalf("expected idle_in_transaction_time converted from ms to 1s, got %v", got)
+	}
+	if got := byName[c.sessions.String()]["app"]; got != 9 {
+		t.Fatalf("expected sessions=9, got %v", got)
+	}
+	if got := byName[c.sessionsAbandoned.String()]["app"]; got != 1 {
+		t.Fatalf("expected sessions_abandoned=1, got %v", got)
+	}
+	if got := byName[c.sessionsFatal.String()]["app"]; got != 2 {
+		t.Fatalf("expected sessions_fatal=2, got %v", got)
+	}
+	if got := byName[c.sessionsKilled.String()]["app"]; got != 3 {
+		t.Fatalf("expected sessions_killed=3, got %v", got)
+	}
+	if _, ok := byName[c.checksumLastFailure.String()]; ok {
+		t.Fatal("expected checksum_last_failure to be skipped entirely: the column was NULL")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unfulfilled sqlmock expectations: %v", err)
+	}
+}
+
+func strPtr(s string) *string { return &s }